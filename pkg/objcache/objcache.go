@@ -20,9 +20,14 @@ package objcache
 
 import (
 	"bytes"
+	"container/list"
+	"encoding/gob"
 	"errors"
+	"fmt"
 	"io"
+	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/valyala/bytebufferpool"
@@ -38,6 +43,17 @@ const (
 	// defaultBufferRatio represents default ratio used to calculate the
 	// individual cache entry buffer size.
 	defaultBufferRatio = uint64(10)
+
+	// defaultShardCount is the number of shards a Cache is split into
+	// when the caller does not pick one via WithShards/NewSharded. This
+	// mirrors what bigcache-style sharded caches default to in order to
+	// scale on many-core machines.
+	defaultShardCount = 256
+
+	// fnvOffsetBasis64 and fnvPrime64 are the FNV-1a 64-bit constants
+	// used by shardFor.
+	fnvOffsetBasis64 = uint64(14695981039346656037)
+	fnvPrime64       = uint64(1099511628211)
 )
 
 var (
@@ -51,54 +67,148 @@ var (
 	ErrExcessData = errors.New("Attempted excess write on cache")
 )
 
+// EvictionPolicy determines which entry is chosen for eviction
+// when an incoming entry would otherwise push a shard's currentSize
+// over its share of maxSize.
+type EvictionPolicy int
+
+const (
+	// EvictionPolicyLRU evicts the least-recently-Open'ed entry first.
+	// Every successful Open moves the entry to the front of the
+	// eviction order.
+	EvictionPolicyLRU EvictionPolicy = iota
+
+	// EvictionPolicyFIFO evicts the oldest-inserted entry first,
+	// regardless of how often it has been Open'ed.
+	EvictionPolicyFIFO
+)
+
+// Option configures optional Cache behavior, set at construction time
+// via New.
+type Option func(*Cache)
+
+// WithEvictionPolicy sets the eviction policy used to reclaim space when
+// a Create/Close would push a shard's currentSize over its share of
+// maxSize. Defaults to EvictionPolicyLRU.
+func WithEvictionPolicy(policy EvictionPolicy) Option {
+	return func(c *Cache) {
+		c.evictionPolicy = policy
+	}
+}
+
+// WithShards sets the number of shards the cache is split into. n is
+// rounded up to the next power of two. Defaults to defaultShardCount.
+func WithShards(n int) Option {
+	return func(c *Cache) {
+		c.numShards = n
+	}
+}
+
 // buffer represents the in memory cache of a single entry.
 // buffer carries value of the data and last accessed time.
 type buffer struct {
 	buf          *bytebufferpool.ByteBuffer
 	lastAccessed time.Time // Represents time when value was last accessed.
+
+	// element is this entry's node in the owning shard's order list,
+	// used to track insertion (FIFO) or last-access (LRU) order for
+	// eviction.
+	element *list.Element
+
+	// refs is an atomic reference count: 1 for the cache's own entry,
+	// plus 1 per outstanding reader handed out by Open/GetAll. buf is
+	// only reset and returned to bytebufferpool once refs drops to
+	// zero, so a concurrent Delete or eviction can never invalidate
+	// memory a caller is still reading.
+	refs int32
 }
 
-// Cache holds the required variables to compose an in memory cache system
-// which also provides expiring key mechanism and also maxSize.
-type Cache struct {
-	// Mutex is used for handling the concurrent
-	// read/write requests for cache
-	mutex sync.Mutex
+// acquire registers an additional reader of b.
+func (b *buffer) acquire() {
+	atomic.AddInt32(&b.refs, 1)
+}
+
+// release drops a reference to b, returning its buf to bytebufferpool
+// once the last reference - cache entry or reader - is gone.
+func (b *buffer) release() {
+	if atomic.AddInt32(&b.refs, -1) == 0 {
+		b.buf.Reset()
+		bytebufferpool.Put(b.buf)
+	}
+}
+
+// shard is an independently locked partition of the Cache. Keys are
+// routed to a shard by hashing, so a slow eviction or a long read in one
+// shard never stalls callers touching keys in another.
+type shard struct {
+	mutex sync.RWMutex
+
+	// map of cached keys and its values, scoped to this shard.
+	entries map[string]*buffer
+
+	// order tracks this shard's entries from least to most relevant
+	// for eviction purposes - oldest-inserted (FIFO) or
+	// least-recently-Open'ed (LRU) sits at the back, most relevant at
+	// the front.
+	order *list.List
 
-	// Once is used for resetting GC once after
-	// peak cache usage.
-	onceGC sync.Once
+	// currentSize is the current size in memory of this shard alone.
+	currentSize uint64
 
+	// totalEvicted counter to keep track of this shard's total evictions.
+	totalEvicted int
+}
+
+// Cache holds the required variables to compose an in memory cache system
+// which also provides expiring key mechanism and also maxSize. Internally
+// the cache is split into shards to avoid a single lock serializing every
+// Create/Open across all keys.
+type Cache struct {
 	// maxSize is a total size for overall cache
 	maxSize uint64
 
 	// maxCacheEntrySize is a total size per key buffer.
 	maxCacheEntrySize uint64
 
-	// currentSize is a current size in memory
-	currentSize uint64
-
 	// OnEviction - callback function for eviction
 	OnEviction func(key string)
 
-	// totalEvicted counter to keep track of total expirys
-	totalEvicted int
-
-	// map of cached keys and its values
-	entries map[string]*buffer
+	// evictionPolicy selects how entries are chosen for eviction when a
+	// shard's currentSize would otherwise exceed shardMaxSize.
+	evictionPolicy EvictionPolicy
 
 	// Expiry in time duration.
 	expiry time.Duration
 
 	// Stop garbage collection routine, stops any running GC routine.
 	stopGC chan struct{}
+
+	// numShards is the number of shards requested via WithShards,
+	// rounded up to a power of two and recorded in len(shards).
+	numShards int
+
+	// shards partition entries by fnv1a(key) & shardMask.
+	shards []*shard
+
+	// shardMask is len(shards)-1, used to route a key's hash to a shard.
+	shardMask uint64
+
+	// shardMaxSize is maxSize divided evenly across shards; each shard
+	// evicts independently once its own currentSize exceeds this. It is
+	// never allowed to drop below maxCacheEntrySize - otherwise a high
+	// shard count (the default is 256) would silently shrink the
+	// largest cacheable entry well below what maxCacheEntrySize
+	// promises, rejecting objects that fit comfortably in the cache as
+	// a whole. The tradeoff is that total memory use can exceed maxSize
+	// by up to maxCacheEntrySize per shard in the worst case.
+	shardMaxSize uint64
 }
 
 // New - Return a new cache with a given default expiry
 // duration. If the expiry duration is less than one
 // (or NoExpiry), the items in the cache never expire
 // (by default), and must be deleted manually.
-func New(maxSize uint64, expiry time.Duration) (c *Cache, err error) {
+func New(maxSize uint64, expiry time.Duration, opts ...Option) (c *Cache, err error) {
 	if maxSize == 0 {
 		err = errors.New("invalid maximum cache size")
 		return c, err
@@ -117,13 +227,32 @@ func New(maxSize uint64, expiry time.Duration) (c *Cache, err error) {
 	}()
 
 	c = &Cache{
-		onceGC:            sync.Once{},
 		maxSize:           maxSize,
 		maxCacheEntrySize: maxCacheEntrySize,
-		entries:           make(map[string]*buffer),
+		evictionPolicy:    EvictionPolicyLRU,
+		numShards:         defaultShardCount,
 		expiry:            expiry,
 	}
 
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	c.numShards = roundUpToPowerOfTwo(c.numShards)
+	c.shardMask = uint64(c.numShards - 1)
+	c.shardMaxSize = maxSize / uint64(c.numShards)
+	if c.shardMaxSize < c.maxCacheEntrySize {
+		c.shardMaxSize = c.maxCacheEntrySize
+	}
+
+	c.shards = make([]*shard, c.numShards)
+	for i := range c.shards {
+		c.shards[i] = &shard{
+			entries: make(map[string]*buffer),
+			order:   list.New(),
+		}
+	}
+
 	// We have expiry start the janitor routine.
 	if expiry > 0 {
 		// Initialize a new stop GC channel.
@@ -136,96 +265,338 @@ func New(maxSize uint64, expiry time.Duration) (c *Cache, err error) {
 	return c, nil
 }
 
+// NewSharded - like New, but with the shard count set explicitly instead
+// of defaulting to defaultShardCount. shards is rounded up to the next
+// power of two.
+func NewSharded(maxSize uint64, expiry time.Duration, shards int) (*Cache, error) {
+	return New(maxSize, expiry, WithShards(shards))
+}
+
+// roundUpToPowerOfTwo returns the smallest power of two >= n, or 1 if
+// n <= 1.
+func roundUpToPowerOfTwo(n int) int {
+	p := 1
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
+
+// shardFor returns the shard that owns key, chosen by hashing key with
+// FNV-1a and masking - the same approach bigcache uses to spread keys
+// evenly across shards. The hash is computed inline rather than via
+// hash/fnv's hash.Hash64, which would allocate a new hasher on every
+// call - unacceptable on a path this sharding exists specifically to
+// keep cheap.
+func (c *Cache) shardFor(key string) *shard {
+	h := fnvOffsetBasis64
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= fnvPrime64
+	}
+	return c.shards[h&c.shardMask]
+}
+
 // Create - validates if object size fits with in cache size limit and returns a io.WriteCloser
 // to which object contents can be written and finally Close()'d. During Close() we
 // checks if the amount of data written is equal to the size of the object, in which
-// case it saves the contents to object cache.
+// case it saves the contents to object cache. If saving the new entry would push
+// its shard's currentSize over shardMaxSize, entries are evicted - according to the
+// configured EvictionPolicy - until it fits.
 func (c *Cache) Create(key string) (wc io.WriteCloser) {
 	buf := bytebufferpool.Get()
+	s := c.shardFor(key)
+	limit := c.maxCacheEntrySize
 
 	// Function called on close which saves the object contents
 	// to the object cache.
 	onClose := func() error {
-		c.mutex.Lock()
-		defer c.mutex.Unlock()
+		s.mutex.Lock()
 
 		if buf.Len() == 0 {
 			buf.Reset()
 			bytebufferpool.Put(buf)
+			s.mutex.Unlock()
 
 			// If nothing is written in the buffer
 			// the key is not stored.
 			return nil
 		}
 
-		if uint64(buf.Len()) > c.maxCacheEntrySize {
+		if uint64(buf.Len()) > limit {
 			buf.Reset()
 			bytebufferpool.Put(buf)
+			s.mutex.Unlock()
 
 			return ErrCacheFull
 		}
 
-		// Full object available in buf, save it to cache.
-		c.entries[key] = &buffer{
+		// A previous entry under the same key is replaced wholesale,
+		// drop it first so its size and order node don't linger.
+		if _, ok := s.entries[key]; ok {
+			s.delete(key)
+		}
+
+		// Full object available in buf, save it to cache. refs starts
+		// at 1, representing this cache entry's own ownership of buf.
+		s.entries[key] = &buffer{
 			buf:          buf,
 			lastAccessed: time.Now().UTC(), // Save last accessed time.
+			element:      s.order.PushFront(key),
+			refs:         1,
 		}
 
 		// Account for the memory allocated above.
-		c.currentSize += uint64(buf.Len())
+		s.currentSize += uint64(buf.Len())
+
+		// Reclaim space for the new entry if we are now over budget.
+		evicted := s.evict(c.shardMaxSize)
+		s.mutex.Unlock()
+
+		for _, k := range evicted {
+			if c.OnEviction != nil {
+				c.OnEviction(k)
+			}
+		}
 		return nil
 	}
 
 	return &writeCloser{ByteBuffer: buf, onClose: onClose}
 }
 
-// Open - open the in-memory file, returns an in memory reader.
-// returns an error ErrKeyNotFoundInCache, if the key does not
-// exist. ErrKeyNotFoundInCache is also returned if lastAccessed
-// is older than input atime.
-func (c *Cache) Open(key string, atime time.Time) (io.Reader, error) {
-	// Entry exists, return the readable buffer.
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
-	b, ok := c.entries[key]
-	if !ok {
-		return nil, ErrKeyNotFoundInCache
-	}
+// Open - open the in-memory file, returns a reference-counted reader
+// over the cached bytes. The caller must Close the returned
+// io.ReadCloser once done; Close releases Open's reference so a
+// concurrent Delete or eviction can safely reset and reuse the
+// underlying buffer once every outstanding reader is finished with it.
+// Open returns ErrKeyNotFoundInCache if the key does not exist.
+// ErrKeyNotFoundInCache is also returned if lastAccessed is older than
+// input atime, or if the entry has actively expired - Open does not
+// wait for the next janitor tick to drop stale data.
+func (c *Cache) Open(key string, atime time.Time) (io.ReadCloser, error) {
+	s := c.shardFor(key)
+
+	// Common path: look the entry up under a read lock so concurrent
+	// lookups on the same shard don't block each other; the write lock
+	// is then held only briefly to refresh lastAccessed.
+	s.mutex.RLock()
+	b, ok := s.entries[key]
+	if ok && !b.lastAccessed.Before(atime) && !c.isExpired(b) {
+		b.acquire()
+		data := b.buf.Bytes()
+		s.mutex.RUnlock()
+
+		// Refreshing lastAccessed (and, under LRU, the shard's order)
+		// mutates shared state, so briefly upgrade to the write lock.
+		s.mutex.Lock()
+		b.lastAccessed = time.Now()
+		if c.evictionPolicy == EvictionPolicyLRU {
+			s.order.MoveToFront(b.element)
+		}
+		s.mutex.Unlock()
 
-	// Check if buf was recently accessed.
-	if b.lastAccessed.Before(atime) {
-		c.delete(key)
-		return nil, ErrKeyNotFoundInCache
+		return &refCountedReader{Reader: bytes.NewReader(data), buffer: b}, nil
+	}
+	s.mutex.RUnlock()
+
+	// The entry is missing, stale, or actively expired. Take the write
+	// lock and delete it inline rather than leaving it for the janitor.
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if b, ok = s.entries[key]; ok && (b.lastAccessed.Before(atime) || c.isExpired(b)) {
+		s.delete(key)
 	}
+	return nil, ErrKeyNotFoundInCache
+}
 
-	b.lastAccessed = time.Now()
-	return bytes.NewReader(b.buf.Bytes()), nil
+// isExpired reports whether b has passed c.expiry since it was last
+// accessed. Callers must hold the owning shard's mutex (read or write).
+func (c *Cache) isExpired(b *buffer) bool {
+	return c.expiry > 0 && time.Now().UTC().Sub(b.lastAccessed) > c.expiry
 }
 
 // Delete - delete deletes an entry from the cache.
 func (c *Cache) Delete(key string) {
-	c.mutex.Lock()
-	c.delete(key)
-	c.mutex.Unlock()
+	s := c.shardFor(key)
+	s.mutex.Lock()
+	s.delete(key)
+	s.mutex.Unlock()
 	if c.OnEviction != nil {
 		c.OnEviction(key)
 	}
 }
 
-// gc - garbage collect all the expired entries from the cache.
-func (c *Cache) gc() {
-	var evictedEntries []string
-	c.mutex.Lock()
-	for k, v := range c.entries {
-		if c.expiry > 0 && time.Now().UTC().Sub(v.lastAccessed) > c.expiry {
-			c.delete(k)
-			evictedEntries = append(evictedEntries, k)
+// keysByShard groups the distinct keys in keys by the shard that owns
+// them, so batch operations can take each shard's lock once instead of
+// once per key. Duplicates are dropped: GetAll acquires one reference
+// per group entry, and a duplicate would be acquired twice while only
+// the last reader survives in the result map, permanently leaking the
+// dropped reference's hold on the underlying buffer.
+func (c *Cache) keysByShard(keys []string) map[*shard][]string {
+	grouped := make(map[*shard][]string)
+	seen := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		if _, ok := seen[key]; ok {
+			continue
 		}
+		seen[key] = struct{}{}
+
+		s := c.shardFor(key)
+		grouped[s] = append(grouped[s], key)
 	}
-	c.mutex.Unlock()
-	for _, k := range evictedEntries {
-		if c.OnEviction != nil {
-			c.OnEviction(k)
+	return grouped
+}
+
+// GetAll - opens every key present in keys, skipping expired or missing
+// ones, and returns a map of reference-counted readers for the entries
+// found. As with Open, callers must Close each returned io.ReadCloser.
+// GetAll takes each affected shard's mutex once for the whole batch
+// rather than once per key, which matters when invalidating many keys
+// under a shared bucket/prefix.
+func (c *Cache) GetAll(keys []string) map[string]io.ReadCloser {
+	readers := make(map[string]io.ReadCloser, len(keys))
+
+	for s, shardKeys := range c.keysByShard(keys) {
+		s.mutex.Lock()
+		for _, key := range shardKeys {
+			b, ok := s.entries[key]
+			if !ok || c.isExpired(b) {
+				continue
+			}
+
+			b.lastAccessed = time.Now()
+			if c.evictionPolicy == EvictionPolicyLRU {
+				s.order.MoveToFront(b.element)
+			}
+
+			b.acquire()
+			readers[key] = &refCountedReader{Reader: bytes.NewReader(b.buf.Bytes()), buffer: b}
+		}
+		s.mutex.Unlock()
+	}
+	return readers
+}
+
+// DeleteAll - deletes every key present in keys. Keys that are not
+// present are silently ignored. Each affected shard's mutex is taken
+// once for the whole batch rather than once per key.
+func (c *Cache) DeleteAll(keys []string) {
+	var evicted []string
+	for s, shardKeys := range c.keysByShard(keys) {
+		s.mutex.Lock()
+		for _, key := range shardKeys {
+			if _, ok := s.entries[key]; ok {
+				s.delete(key)
+				evicted = append(evicted, key)
+			}
+		}
+		s.mutex.Unlock()
+	}
+
+	if c.OnEviction != nil {
+		for _, key := range evicted {
+			c.OnEviction(key)
+		}
+	}
+}
+
+// Keys - returns a snapshot slice of all cached keys matching pattern, a
+// glob supporting `*` (any run of characters, including `/`) and `?`
+// (any single character). Unlike path.Match, `*` here deliberately
+// crosses path separators: object keys are not filesystem paths, and a
+// pattern like "mybucket/*" must invalidate every key nested under that
+// prefix - "mybucket/dir/obj" included - not just keys one segment
+// deep. Shards are scanned one at a time under their own lock, so Keys
+// never blocks the whole cache at once. The returned slice is safe to
+// range over after the call returns, without holding any lock.
+func (c *Cache) Keys(pattern string) []string {
+	var matches []string
+	for _, s := range c.shards {
+		s.mutex.RLock()
+		for key := range s.entries {
+			if globMatch(pattern, key) {
+				matches = append(matches, key)
+			}
+		}
+		s.mutex.RUnlock()
+	}
+	return matches
+}
+
+// globMatch reports whether name matches pattern, where `*` matches any
+// run of characters (including `/`) and `?` matches exactly one
+// character. It is the classic two-pointer wildcard matcher with
+// backtracking to the most recent `*` on a mismatch.
+func globMatch(pattern, name string) bool {
+	var pIdx, nIdx, star, match int
+	star = -1
+
+	for nIdx < len(name) {
+		switch {
+		case pIdx < len(pattern) && (pattern[pIdx] == '?' || pattern[pIdx] == name[nIdx]):
+			pIdx++
+			nIdx++
+		case pIdx < len(pattern) && pattern[pIdx] == '*':
+			star = pIdx
+			match = nIdx
+			pIdx++
+		case star != -1:
+			pIdx = star + 1
+			match++
+			nIdx = match
+		default:
+			return false
+		}
+	}
+
+	for pIdx < len(pattern) && pattern[pIdx] == '*' {
+		pIdx++
+	}
+	return pIdx == len(pattern)
+}
+
+// CurrentSize returns the total number of bytes currently cached,
+// aggregated across all shards on demand.
+func (c *Cache) CurrentSize() uint64 {
+	var total uint64
+	for _, s := range c.shards {
+		s.mutex.RLock()
+		total += s.currentSize
+		s.mutex.RUnlock()
+	}
+	return total
+}
+
+// TotalEvicted returns the total number of entries evicted or deleted
+// since the cache was created, aggregated across all shards on demand.
+func (c *Cache) TotalEvicted() int {
+	var total int
+	for _, s := range c.shards {
+		s.mutex.RLock()
+		total += s.totalEvicted
+		s.mutex.RUnlock()
+	}
+	return total
+}
+
+// gc - garbage collect all the expired entries from the cache. Shards
+// are walked independently, so a slow eviction on one shard never stalls
+// reads on another.
+func (c *Cache) gc() {
+	for _, s := range c.shards {
+		var evictedEntries []string
+		s.mutex.Lock()
+		for k, v := range s.entries {
+			if c.isExpired(v) {
+				s.delete(k)
+				evictedEntries = append(evictedEntries, k)
+			}
+		}
+		s.mutex.Unlock()
+		for _, k := range evictedEntries {
+			if c.OnEviction != nil {
+				c.OnEviction(k)
+			}
 		}
 	}
 }
@@ -257,14 +628,185 @@ func (c *Cache) StartGC() {
 	}()
 }
 
-// Deletes a requested entry from the cache.
-func (c *Cache) delete(key string) {
-	if _, ok := c.entries[key]; ok {
-		deletedSize := uint64(c.entries[key].buf.Len())
-		c.entries[key].buf.Reset()
-		bytebufferpool.Put(c.entries[key].buf)
-		delete(c.entries, key)
-		c.currentSize -= deletedSize
-		c.totalEvicted++
+// evict reclaims space until s.currentSize is back under maxSize,
+// taking from the back of s.order - the oldest-inserted entry under
+// FIFO, or the least-recently-Open'ed entry under LRU, since Open keeps
+// that list ordered for the active policy. Callers must hold s.mutex.
+// Returns the keys evicted so OnEviction can be fired once the lock is
+// released.
+func (s *shard) evict(maxSize uint64) (evicted []string) {
+	for s.currentSize > maxSize {
+		back := s.order.Back()
+		if back == nil {
+			break
+		}
+		key := back.Value.(string)
+		s.delete(key)
+		evicted = append(evicted, key)
+	}
+	return evicted
+}
+
+// delete removes a requested entry from the shard, dropping the cache's
+// own reference to its buffer. If readers acquired via Open/GetAll are
+// still outstanding, the buffer is only tombstoned here - its final
+// release happens when the last reader Closes.
+func (s *shard) delete(key string) {
+	if b, ok := s.entries[key]; ok {
+		deletedSize := uint64(b.buf.Len())
+		s.order.Remove(b.element)
+		delete(s.entries, key)
+		s.currentSize -= deletedSize
+		s.totalEvicted++
+		b.release()
+	}
+}
+
+// cacheRecord is the gob-encoded representation of a single cache entry,
+// used by Save/Load to persist and restore Cache contents across restarts.
+type cacheRecord struct {
+	Key          string
+	Data         []byte
+	LastAccessed time.Time
+}
+
+// Save - serializes all live entries (key, bytes, lastAccessed) to w using
+// encoding/gob, so a later Load can warm the cache back up instead of
+// paying cold-cache latency on every key after a restart. Shards are
+// visited one at a time, each under its own read lock.
+func (c *Cache) Save(w io.Writer) error {
+	var records []cacheRecord
+	for _, s := range c.shards {
+		s.mutex.RLock()
+		for key, b := range s.entries {
+			if c.isExpired(b) {
+				continue
+			}
+			records = append(records, cacheRecord{
+				Key:          key,
+				Data:         append([]byte(nil), b.buf.Bytes()...),
+				LastAccessed: b.lastAccessed,
+			})
+		}
+		s.mutex.RUnlock()
+	}
+
+	return gob.NewEncoder(w).Encode(records)
+}
+
+// SaveFile - convenience wrapper around Save that writes to the file at
+// filePath, creating or truncating it as needed.
+func (c *Cache) SaveFile(filePath string) error {
+	f, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return c.Save(f)
+}
+
+// Load - restores entries previously written by Save. Entries that
+// don't fit maxCacheEntrySize are skipped; if the restored set would push a
+// shard's currentSize over its shardMaxSize, the configured
+// EvictionPolicy reclaims space exactly as it would for a live Create.
+// Each affected shard's write lock is held for the duration of its
+// portion of the restore. If any entries were skipped, Load returns a
+// summary error after restoring everything that did fit.
+func (c *Cache) Load(r io.Reader) error {
+	var records []cacheRecord
+	if err := gob.NewDecoder(r).Decode(&records); err != nil {
+		return err
+	}
+
+	limit := c.maxCacheEntrySize
+	grouped := make(map[*shard][]cacheRecord)
+	for _, rec := range records {
+		s := c.shardFor(rec.Key)
+		grouped[s] = append(grouped[s], rec)
+	}
+
+	var skipped int
+	var evicted []string
+	for s, shardRecords := range grouped {
+		s.mutex.Lock()
+		for _, rec := range shardRecords {
+			if uint64(len(rec.Data)) > limit {
+				skipped++
+				continue
+			}
+
+			if _, ok := s.entries[rec.Key]; ok {
+				s.delete(rec.Key)
+			}
+
+			buf := bytebufferpool.Get()
+			buf.Write(rec.Data)
+			s.entries[rec.Key] = &buffer{
+				buf:          buf,
+				lastAccessed: rec.LastAccessed,
+				element:      s.order.PushFront(rec.Key),
+				refs:         1,
+			}
+			s.currentSize += uint64(buf.Len())
+			evicted = append(evicted, s.evict(c.shardMaxSize)...)
+		}
+		s.mutex.Unlock()
+	}
+
+	for _, key := range evicted {
+		if c.OnEviction != nil {
+			c.OnEviction(key)
+		}
+	}
+
+	if skipped > 0 {
+		return fmt.Errorf("objcache: skipped %d of %d entries that exceeded the cache entry size limit during Load", skipped, len(records))
+	}
+	return nil
+}
+
+// LoadFile - convenience wrapper around Load that reads from the file at
+// filePath.
+func (c *Cache) LoadFile(filePath string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
 	}
+	defer f.Close()
+
+	return c.Load(f)
+}
+
+// refCountedReader is the io.ReadCloser returned by Open/GetAll. It
+// reads directly out of the cached buffer's bytes without copying them,
+// and releases its reference on Close so the buffer can be safely
+// reused by bytebufferpool once every reader and the cache entry itself
+// have let go of it.
+type refCountedReader struct {
+	*bytes.Reader
+	buffer *buffer
+	closed int32 // atomic; guards against releasing twice on a double Close.
+}
+
+// Close - implements io.Closer, releasing this reader's reference on
+// the underlying buffer.
+func (r *refCountedReader) Close() error {
+	if atomic.CompareAndSwapInt32(&r.closed, 0, 1) {
+		r.buffer.release()
+	}
+	return nil
+}
+
+// writeCloser adapts a *bytebufferpool.ByteBuffer into an io.WriteCloser,
+// invoking onClose to commit the buffer to the cache once the caller is
+// done writing.
+type writeCloser struct {
+	*bytebufferpool.ByteBuffer
+	onClose func() error
+}
+
+// Close - implements io.Closer, saves the buffer contents into the cache.
+func (w *writeCloser) Close() error {
+	return w.onClose()
 }
@@ -0,0 +1,429 @@
+/*
+ * Minio Cloud Storage, (C) 2021 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package objcache
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// write stores data under key via Create/Close, failing the test on error.
+func write(t *testing.T, c *Cache, key string, data []byte) {
+	t.Helper()
+	wc := c.Create(key)
+	if _, err := wc.Write(data); err != nil {
+		t.Fatalf("Write(%s): %v", key, err)
+	}
+	if err := wc.Close(); err != nil {
+		t.Fatalf("Close(%s): %v", key, err)
+	}
+}
+
+// mustOpenAndClose opens key, drains and discards its contents, and
+// closes the reader, failing the test if key is not present and
+// readable.
+func mustOpenAndClose(t *testing.T, c *Cache, key string) {
+	t.Helper()
+	r, err := c.Open(key, time.Time{})
+	if err != nil {
+		t.Fatalf("Open(%s): %v", key, err)
+	}
+	defer r.Close()
+	if _, err := io.ReadAll(r); err != nil {
+		t.Fatalf("ReadAll(%s): %v", key, err)
+	}
+}
+
+// TestEvictionPolicy fills a single-shard cache to exactly its capacity
+// with fixed-size entries, then writes one more to force an eviction,
+// asserting FIFO always drops the oldest insert while LRU spares
+// whichever entry was most recently Open'ed.
+func TestEvictionPolicy(t *testing.T) {
+	const maxSize = 100 // maxCacheEntrySize works out to 10, so 10 entries exactly fill the cache.
+	payload := []byte("0123456789")
+
+	newFilledCache := func(t *testing.T, policy EvictionPolicy) *Cache {
+		t.Helper()
+		c, err := New(maxSize, NoExpiry, WithShards(1), WithEvictionPolicy(policy))
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		for i := 0; i < 10; i++ {
+			write(t, c, fmt.Sprintf("k%d", i), payload)
+		}
+		return c
+	}
+
+	t.Run("FIFO evicts the oldest insert regardless of recent Opens", func(t *testing.T) {
+		c := newFilledCache(t, EvictionPolicyFIFO)
+		mustOpenAndClose(t, c, "k0") // refreshing k0 must not save it under FIFO
+
+		write(t, c, "k10", payload) // overflow by exactly one entry
+
+		if _, err := c.Open("k0", time.Time{}); err != ErrKeyNotFoundInCache {
+			t.Fatalf("expected k0 (oldest insert) evicted under FIFO, got err=%v", err)
+		}
+		mustOpenAndClose(t, c, "k1") // next-oldest should still be present
+	})
+
+	t.Run("LRU spares the most recently opened entry", func(t *testing.T) {
+		c := newFilledCache(t, EvictionPolicyLRU)
+		mustOpenAndClose(t, c, "k0") // refresh k0 to the front of the LRU order
+
+		write(t, c, "k10", payload) // overflow by exactly one entry
+
+		mustOpenAndClose(t, c, "k0") // survives because it was refreshed
+		if _, err := c.Open("k1", time.Time{}); err != ErrKeyNotFoundInCache {
+			t.Fatalf("expected k1 (now least-recently-used) evicted under LRU, got err=%v", err)
+		}
+	})
+}
+
+// TestOpenActivelyExpiresStaleEntries asserts that Open itself drops a
+// stale entry and returns ErrKeyNotFoundInCache as soon as expiry has
+// passed, rather than relying on the next janitor tick. The janitor is
+// stopped immediately after construction so the only way the entry
+// could be found missing is Open's own inline check.
+func TestOpenActivelyExpiresStaleEntries(t *testing.T) {
+	const expiry = 30 * time.Millisecond
+	c, err := New(1024, expiry, WithShards(1))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	c.StopGC()
+
+	write(t, c, "stale", []byte("payload"))
+	time.Sleep(expiry + 10*time.Millisecond)
+
+	if _, err := c.Open("stale", time.Time{}); err != ErrKeyNotFoundInCache {
+		t.Fatalf("expected Open to actively expire a stale entry, got err=%v", err)
+	}
+}
+
+// TestRoundUpToPowerOfTwo checks the shard count rounding used by New to
+// turn an arbitrary WithShards(n) into a power of two suitable for
+// masking a key's hash.
+func TestRoundUpToPowerOfTwo(t *testing.T) {
+	cases := []struct{ in, want int }{
+		{0, 1},
+		{1, 1},
+		{2, 2},
+		{3, 4},
+		{5, 8},
+		{256, 256},
+		{257, 512},
+	}
+	for _, tc := range cases {
+		if got := roundUpToPowerOfTwo(tc.in); got != tc.want {
+			t.Errorf("roundUpToPowerOfTwo(%d) = %d, want %d", tc.in, got, tc.want)
+		}
+	}
+}
+
+// TestShardMaxSizeAtLeastEntryLimit guards against a high shard count
+// dividing maxSize down so far that a shard's own budget falls below
+// maxCacheEntrySize - which would silently reject entries that
+// maxCacheEntrySize otherwise promises room for.
+func TestShardMaxSizeAtLeastEntryLimit(t *testing.T) {
+	c, err := New(1000, NoExpiry, WithShards(256))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if len(c.shards) != 256 {
+		t.Fatalf("len(shards) = %d, want 256", len(c.shards))
+	}
+	if c.shardMaxSize < c.maxCacheEntrySize {
+		t.Fatalf("shardMaxSize (%d) < maxCacheEntrySize (%d)", c.shardMaxSize, c.maxCacheEntrySize)
+	}
+
+	// An entry right at maxCacheEntrySize must still fit, no matter which
+	// shard it happens to land in.
+	payload := bytes.Repeat([]byte("x"), int(c.maxCacheEntrySize))
+	write(t, c, "big-entry", payload)
+	mustOpenAndClose(t, c, "big-entry")
+}
+
+// TestGlobMatch table-tests the wildcard matcher behind Keys, in
+// particular that `*` crosses `/` - the case path.Match gets wrong and
+// that broke bucket/prefix invalidation before it was replaced.
+func TestGlobMatch(t *testing.T) {
+	cases := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"mybucket/*", "mybucket/obj", true},
+		{"mybucket/*", "mybucket/dir/obj", true}, // * must cross path segments
+		{"mybucket/*", "otherbucket/obj", false},
+		{"*", "anything/at/all", true},
+		{"a?c", "abc", true},
+		{"a?c", "ac", false},
+		{"exact", "exact", true},
+		{"exact", "exactly", false},
+		{"*.txt", "notes.txt", true},
+		{"*.txt", "notes.md", false},
+	}
+	for _, tc := range cases {
+		if got := globMatch(tc.pattern, tc.name); got != tc.want {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", tc.pattern, tc.name, got, tc.want)
+		}
+	}
+}
+
+// TestGetAllDeleteAll exercises GetAll/DeleteAll with a key slice that
+// contains a duplicate and a missing key, asserting both that the
+// duplicate doesn't leak the extra reference keysByShard's dedupe exists
+// to prevent, and that a missing key is silently skipped rather than
+// producing an entry or an error.
+func TestGetAllDeleteAll(t *testing.T) {
+	c, err := New(1024, NoExpiry, WithShards(4))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	write(t, c, "a", []byte("aaa"))
+	write(t, c, "b", []byte("bbb"))
+
+	readers := c.GetAll([]string{"a", "a", "b", "missing"})
+	if len(readers) != 2 {
+		t.Fatalf("len(readers) = %d, want 2", len(readers))
+	}
+	for key, want := range map[string]string{"a": "aaa", "b": "bbb"} {
+		r, ok := readers[key]
+		if !ok {
+			t.Fatalf("missing reader for %q", key)
+		}
+		got, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("ReadAll(%s): %v", key, err)
+		}
+		if string(got) != want {
+			t.Fatalf("GetAll(%s) = %q, want %q", key, got, want)
+		}
+	}
+	if _, ok := readers["missing"]; ok {
+		t.Fatalf("GetAll returned a reader for a missing key")
+	}
+
+	// The duplicate "a" must only have acquired (and released) one
+	// reference beyond the cache entry's own baseline - if keysByShard
+	// didn't dedupe, this would be stuck at 2 forever.
+	s := c.shardFor("a")
+	s.mutex.RLock()
+	refs := atomic.LoadInt32(&s.entries["a"].refs)
+	s.mutex.RUnlock()
+	if refs != 1 {
+		t.Fatalf("refs for \"a\" after GetAll+Close = %d, want 1 (leaked reference from duplicate key)", refs)
+	}
+
+	c.DeleteAll([]string{"a", "a", "missing"})
+	if _, err := c.Open("a", time.Time{}); err != ErrKeyNotFoundInCache {
+		t.Fatalf("expected a deleted by DeleteAll, got err=%v", err)
+	}
+	mustOpenAndClose(t, c, "b") // untouched by DeleteAll
+}
+
+// TestSaveLoadRoundTrip saves a populated cache's contents and restores
+// them into a fresh Cache, asserting every entry reads back intact.
+func TestSaveLoadRoundTrip(t *testing.T) {
+	c, err := New(1024, NoExpiry, WithShards(4))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	write(t, c, "a", []byte("aaa"))
+	write(t, c, "b", []byte("bbb"))
+
+	var buf bytes.Buffer
+	if err := c.Save(&buf); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	restored, err := New(1024, NoExpiry, WithShards(4))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := restored.Load(&buf); err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	for key, want := range map[string]string{"a": "aaa", "b": "bbb"} {
+		r, err := restored.Open(key, time.Time{})
+		if err != nil {
+			t.Fatalf("Open(%s) after Load: %v", key, err)
+		}
+		got, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("ReadAll(%s): %v", key, err)
+		}
+		if string(got) != want {
+			t.Fatalf("Open(%s) after Load = %q, want %q", key, got, want)
+		}
+	}
+}
+
+// TestLoadSkipsOversizedEntries asserts that Load skips a record whose
+// Data exceeds the destination cache's maxCacheEntrySize and reports it
+// in the summary error, without disturbing entries that do fit.
+func TestLoadSkipsOversizedEntries(t *testing.T) {
+	const maxSize = 100 // maxCacheEntrySize works out to 10.
+
+	fitsSrc, err := New(maxSize, NoExpiry, WithShards(1))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	write(t, fitsSrc, "fits", []byte("0123456789")) // exactly 10 bytes
+	var fitsBuf bytes.Buffer
+	if err := fitsSrc.Save(&fitsBuf); err != nil {
+		t.Fatalf("Save(fits): %v", err)
+	}
+
+	// A larger source cache can hold an entry too big for the
+	// destination's maxCacheEntrySize; its own Create would reject that
+	// entry directly, so this is the only way to get such a record onto
+	// the wire for Load to reject.
+	bigSrc, err := New(10000, NoExpiry, WithShards(1))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	write(t, bigSrc, "too-big", bytes.Repeat([]byte("x"), 50))
+	var bigBuf bytes.Buffer
+	if err := bigSrc.Save(&bigBuf); err != nil {
+		t.Fatalf("Save(big): %v", err)
+	}
+
+	dest, err := New(maxSize, NoExpiry, WithShards(1))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := dest.Load(&fitsBuf); err != nil {
+		t.Fatalf("Load(fits): %v", err)
+	}
+	if err := dest.Load(&bigBuf); err == nil {
+		t.Fatalf("expected Load to report the skipped oversized entry")
+	}
+
+	mustOpenAndClose(t, dest, "fits")
+	if _, err := dest.Open("too-big", time.Time{}); err != ErrKeyNotFoundInCache {
+		t.Fatalf("expected too-big to be skipped, got err=%v", err)
+	}
+}
+
+// TestConcurrentOpenDeleteIsRaceFree hammers a single key with concurrent
+// writers, deleters, and readers, to exercise the reference counting that
+// protects a buffer from being reset and returned to bytebufferpool while
+// a reader still holds it. Run with -race to get a meaningful signal.
+func TestConcurrentOpenDeleteIsRaceFree(t *testing.T) {
+	c, err := New(1<<20, NoExpiry, WithShards(4))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	const key = "hot-key"
+	payload := bytes.Repeat([]byte("y"), 64)
+	write(t, c, key, payload)
+
+	stop := make(chan struct{})
+	errs := make(chan error, 16)
+	var wg sync.WaitGroup
+
+	// Continuously replaces the entry - Create's onClose deletes and
+	// drops the previous buffer's cache reference while readers may
+	// still be holding one of their own.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			wc := c.Create(key)
+			if _, err := wc.Write(payload); err != nil {
+				errs <- err
+				return
+			}
+			if err := wc.Close(); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}()
+
+	// Deletes the entry outright, racing the writer above.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				c.Delete(key)
+			}
+		}
+	}()
+
+	// Readers must either see a consistent, correctly-sized payload or a
+	// clean ErrKeyNotFoundInCache - never a torn or corrupted read.
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				r, err := c.Open(key, time.Time{})
+				if err == ErrKeyNotFoundInCache {
+					continue
+				}
+				if err != nil {
+					errs <- err
+					return
+				}
+				got, err := io.ReadAll(r)
+				r.Close()
+				if err != nil {
+					errs <- err
+					return
+				}
+				if len(got) != len(payload) {
+					errs <- fmt.Errorf("short read: got %d bytes, want %d", len(got), len(payload))
+					return
+				}
+			}
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(stop)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatal(err)
+	}
+}